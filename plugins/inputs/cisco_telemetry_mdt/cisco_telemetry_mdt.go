@@ -4,17 +4,29 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"math"
 	"net"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"google.golang.org/grpc/metadata"
 
 	"github.com/golang/protobuf/proto"
+	gnmiLib "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
 	internaltls "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
@@ -23,6 +35,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs/cisco_telemetry_mdt/telemetry"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/peer"
 )
 
@@ -32,6 +45,19 @@ const (
 
 	// IOS XR EMS dialin telemetry GPBKV encoding
 	grpcEncodeGPBKV int64 = 3
+
+	// TCP dialout telemetry header msg_encap values
+	tcpEncapGPB  uint16 = 0
+	tcpEncapJSON uint16 = 2
+
+	// Starting wait for a dial-in redial backoff
+	redialBackoffInitial = 500 * time.Millisecond
+
+	// Default ceiling for a dial-in redial backoff, if redial_max is unset
+	redialBackoffDefaultMax = time.Minute
+
+	// A subscription that survives this long resets the redial backoff
+	redialBackoffResetAfter = time.Minute
 )
 
 // CiscoTelemetryMDT plugin for IOS XR, IOS XE and NXOS platforms
@@ -40,13 +66,37 @@ type CiscoTelemetryMDT struct {
 	Transport      string
 	ServiceAddress string `toml:"service_address"`
 
-	// GRPC dialin settings
+	// GRPC dialin settings: a fleet of EMS devices to subscribe to, each with
+	// its own credentials, subscription and TLS settings
+	Devices []CiscoMDTDevice `toml:"devices"`
+
+	// GRPC dialin settings (deprecated for grpc-dialin: use
+	// [[inputs.cisco_telemetry_mdt.devices]] instead). grpc-gnmi still reads
+	// Username, Password and Redial, and MaxMsgSize also applies to grpc-gnmi
+	// and the dialout transports
 	Username     string
 	Password     string
 	Subscription string
 	Redial       internal.Duration
 	MaxMsgSize   int `toml:"max_msg_size"`
 
+	// GRPC gNMI dialin settings (grpc-gnmi transport)
+	GNMIPaths      []string          `toml:"gnmi_paths"`
+	GNMIMode       string            `toml:"gnmi_mode"`
+	GNMIEncoding   string            `toml:"gnmi_encoding"`
+	SampleInterval internal.Duration `toml:"sample_interval"`
+
+	// GRPC dialin/gNMI redial and keepalive settings (client-side)
+	RedialMax           internal.Duration `toml:"redial_max"`
+	KeepaliveTime       internal.Duration `toml:"keepalive_time"`
+	KeepaliveTimeout    internal.Duration `toml:"keepalive_timeout"`
+	PermitWithoutStream bool              `toml:"permit_without_stream"`
+
+	// GRPC dialout keepalive enforcement settings (server-side, grpc-dialout transport)
+	KeepaliveMinTime             internal.Duration `toml:"keepalive_min_time"`
+	KeepaliveMaxConnectionIdle   internal.Duration `toml:"keepalive_max_connection_idle"`
+	KeepalivePermitWithoutStream bool              `toml:"keepalive_permit_without_stream"`
+
 	// GRPC TLS settings
 	EnableTLS          bool     `toml:"enable_tls"`
 	TLSCA              string   `toml:"tls_ca"`
@@ -55,14 +105,119 @@ type CiscoTelemetryMDT struct {
 	InsecureSkipVerify bool     `toml:"insecure_skip_verify"`
 	TLSAllowedCACerts  []string `toml:"tls_allowed_cacerts"`
 
+	// EncodingPaths maps a compact-GPB encoding_path to the compiled
+	// FileDescriptorSet (produced by `protoc --descriptor_set_out`) and the
+	// message within it describing the sensor path's Row.Content, so it can
+	// be decoded without the GPBKV self-describing overhead
+	EncodingPaths map[string]CiscoGPBCompactPath `toml:"encoding_paths"`
+
+	// Subscriptions customizes, per encoding_path, how GPBKV, compact-GPB and
+	// JSON leaves map to the emitted measurement name, tags and fields
+	Subscriptions []CiscoMDTSubscription `toml:"subscription"`
+
+	// EmbedTags opts in to per-device/per-connection identity tags: "source"
+	// (the dial-in device's service_address, or the dialout peer address)
+	// and/or "source_cert_cn" (verified client certificate CN, grpc-dialout
+	// with mTLS only). Off by default so upgrading does not change the
+	// series of existing dashboards
+	EmbedTags []string `toml:"embed_tags"`
+
 	// Internal listener / client handle
 	grpcServer *grpc.Server
 	listener   net.Listener
 
 	// Internal state
-	acc    telegraf.Accumulator
-	cancel context.CancelFunc
-	wg     sync.WaitGroup
+	gpbCompactDescriptors map[string]protoreflect.MessageDescriptor
+	subscriptions         map[string]*resolvedSubscription
+	embedTags             map[string]bool
+	acc                   telegraf.Accumulator
+	cancel                context.CancelFunc
+	wg                    sync.WaitGroup
+}
+
+// CiscoMDTSubscription customizes how the GPBKV, compact-GPB or JSON leaves
+// of a single encoding_path are mapped to a measurement name, tags and fields
+type CiscoMDTSubscription struct {
+	EncodingPath string   `toml:"encoding_path"`
+	Name         string   `toml:"name"`
+	TagFields    []string `toml:"tag_fields"`
+	FieldExclude []string `toml:"field_exclude"`
+	FieldStrings []string `toml:"field_strings"`
+}
+
+// CiscoGPBCompactPath names the compiled descriptor for a compact-GPB
+// encoding_path and, since a descriptor file can describe more than one
+// message, which one corresponds to that path's Row.Content
+type CiscoGPBCompactPath struct {
+	DescriptorFile string `toml:"descriptor_file"`
+	MessageName    string `toml:"message_name"`
+}
+
+// resolvedSubscription is the compiled, lookup-ready form of a CiscoMDTSubscription
+type resolvedSubscription struct {
+	name         string
+	tagFields    map[string]bool
+	fieldExclude filter.Filter
+	fieldStrings map[string]bool
+}
+
+// CiscoMDTDevice defines a single grpc-dialin EMS target, allowing a
+// fleet of routers to be scraped from one plugin instance
+type CiscoMDTDevice struct {
+	ServiceAddress string            `toml:"service_address"`
+	Username       string            `toml:"username"`
+	Password       string            `toml:"password"`
+	Subscriptions  []string          `toml:"subscription"`
+	Redial         internal.Duration `toml:"redial"`
+	RedialMax      internal.Duration `toml:"redial_max"`
+	MaxMsgSize     int               `toml:"max_msg_size"`
+
+	// Keepalive pings to detect and recover from NAT/firewall idled connections
+	KeepaliveTime       internal.Duration `toml:"keepalive_time"`
+	KeepaliveTimeout    internal.Duration `toml:"keepalive_timeout"`
+	PermitWithoutStream bool              `toml:"permit_without_stream"`
+
+	EnableTLS          bool     `toml:"enable_tls"`
+	TLSCA              string   `toml:"tls_ca"`
+	TLSCert            string   `toml:"tls_cert"`
+	TLSKey             string   `toml:"tls_key"`
+	InsecureSkipVerify bool     `toml:"insecure_skip_verify"`
+	TLSAllowedCACerts  []string `toml:"tls_allowed_cacerts"`
+}
+
+// dialOptions builds the grpc.DialOption set for a single dial-in device
+func (d *CiscoMDTDevice) dialOptions() ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+
+	if d.EnableTLS {
+		tlsConfig, err := (&internaltls.ClientConfig{
+			TLSCA:              d.TLSCA,
+			TLSCert:            d.TLSCert,
+			TLSKey:             d.TLSKey,
+			InsecureSkipVerify: d.InsecureSkipVerify,
+		}).TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	if d.MaxMsgSize > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(d.MaxMsgSize)))
+	}
+
+	if d.KeepaliveTime.Duration > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                d.KeepaliveTime.Duration,
+			Timeout:             d.KeepaliveTimeout.Duration,
+			PermitWithoutStream: d.PermitWithoutStream,
+		}))
+	}
+
+	return opts, nil
 }
 
 // Start the Cisco MDT service
@@ -72,6 +227,16 @@ func (c *CiscoTelemetryMDT) Start(acc telegraf.Accumulator) error {
 	c.acc = acc
 	ctx, c.cancel = context.WithCancel(context.Background())
 
+	if err := c.loadGPBCompactDescriptors(); err != nil {
+		return err
+	}
+
+	if err := c.loadSubscriptions(); err != nil {
+		return err
+	}
+
+	c.loadEmbedTags()
+
 	switch c.Transport {
 	case "tcp-dialout":
 		c.listener, err = net.Listen("tcp", c.ServiceAddress)
@@ -103,6 +268,20 @@ func (c *CiscoTelemetryMDT) Start(acc telegraf.Accumulator) error {
 			opts = append(opts, grpc.MaxRecvMsgSize(c.MaxMsgSize))
 		}
 
+		// Enforce and emit keepalive pings so a half-open TCP connection behind a
+		// stateful middlebox doesn't leave MdtDialout's stream.Recv() blocked forever
+		if c.KeepaliveMinTime.Duration > 0 || c.KeepalivePermitWithoutStream {
+			opts = append(opts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+				MinTime:             c.KeepaliveMinTime.Duration,
+				PermitWithoutStream: c.KeepalivePermitWithoutStream,
+			}))
+		}
+		if c.KeepaliveMaxConnectionIdle.Duration > 0 {
+			opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+				MaxConnectionIdle: c.KeepaliveMaxConnectionIdle.Duration,
+			}))
+		}
+
 		c.listener, err = net.Listen("tcp", c.ServiceAddress)
 		if err != nil {
 			return err
@@ -118,6 +297,67 @@ func (c *CiscoTelemetryMDT) Start(acc telegraf.Accumulator) error {
 		}()
 
 	case "grpc-dialin":
+		devices := c.Devices
+		if len(devices) == 0 {
+			log.Printf("W! cisco_telemetry_mdt: no [[inputs.cisco_telemetry_mdt.devices]] configured, " +
+				"falling back to deprecated top-level grpc-dialin settings")
+			devices = []CiscoMDTDevice{{
+				ServiceAddress:      c.ServiceAddress,
+				Username:            c.Username,
+				Password:            c.Password,
+				Subscriptions:       []string{c.Subscription},
+				Redial:              c.Redial,
+				RedialMax:           c.RedialMax,
+				MaxMsgSize:          c.MaxMsgSize,
+				KeepaliveTime:       c.KeepaliveTime,
+				KeepaliveTimeout:    c.KeepaliveTimeout,
+				PermitWithoutStream: c.PermitWithoutStream,
+				EnableTLS:           c.EnableTLS,
+				TLSCA:               c.TLSCA,
+				TLSCert:             c.TLSCert,
+				TLSKey:              c.TLSKey,
+				InsecureSkipVerify:  c.InsecureSkipVerify,
+			}}
+		}
+
+		// Dial every device before starting any goroutine, so a failure partway
+		// through the fleet doesn't leak the connections and goroutines already
+		// started for the devices dialed before it
+		clients := make([]*grpc.ClientConn, 0, len(devices))
+		for i := range devices {
+			// A device that omits "redial" gets Duration's zero value, which
+			// subscribeMDTDialinDevice already treats as "don't redial" (the
+			// same zero-means-off convention as this struct's MaxMsgSize and
+			// KeepaliveTime) rather than a hardcoded default here
+			device := devices[i]
+
+			opts, err := device.dialOptions()
+			if err != nil {
+				for _, client := range clients {
+					client.Close()
+				}
+				return err
+			}
+
+			dialCtx := metadata.AppendToOutgoingContext(ctx, "username", device.Username, "password", device.Password)
+			client, err := grpc.DialContext(dialCtx, device.ServiceAddress, opts...)
+			if err != nil {
+				for _, client := range clients {
+					client.Close()
+				}
+				return fmt.Errorf("failed to dial Cisco MDT device %s: %v", device.ServiceAddress, err)
+			}
+
+			clients = append(clients, client)
+		}
+
+		// Dialin client telemetry stream reading routine, one per device
+		for i := range devices {
+			c.wg.Add(1)
+			go c.subscribeMDTDialinDevice(ctx, clients[i], devices[i])
+		}
+
+	case "grpc-gnmi":
 		var opts []grpc.DialOption
 		ctx = metadata.AppendToOutgoingContext(ctx, "username", c.Username, "password", c.Password)
 
@@ -141,14 +381,22 @@ func (c *CiscoTelemetryMDT) Start(acc telegraf.Accumulator) error {
 			opts = append(opts, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(c.MaxMsgSize)))
 		}
 
+		if c.KeepaliveTime.Duration > 0 {
+			opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+				Time:                c.KeepaliveTime.Duration,
+				Timeout:             c.KeepaliveTimeout.Duration,
+				PermitWithoutStream: c.PermitWithoutStream,
+			}))
+		}
+
 		client, err := grpc.DialContext(ctx, c.ServiceAddress, opts...)
 		if err != nil {
-			return fmt.Errorf("failed to dial Cisco MDT: %v", err)
+			return fmt.Errorf("failed to dial Cisco MDT gNMI device: %v", err)
 		}
 
-		// Dialin client telemetry stream reading routine
+		// Dialin client gNMI Subscribe stream reading routine
 		c.wg.Add(1)
-		go c.subscribeMDTDialinDevice(ctx, client)
+		go c.subscribeMDTDialinGNMIDevice(ctx, client)
 
 	default:
 		return fmt.Errorf("invalid Cisco MDT transport: %s", c.Transport)
@@ -194,6 +442,11 @@ func (c *CiscoTelemetryMDT) acceptTCPDialoutClients(ctx context.Context) {
 
 			var payload bytes.Buffer
 
+			connTags := make(map[string]string)
+			if c.embedTags["source"] {
+				connTags["source"] = conn.RemoteAddr().String()
+			}
+
 			for ctx.Err() == nil {
 				// Read and validate dialout telemetry header
 				if err := binary.Read(conn, binary.BigEndian, &hdr); err != nil {
@@ -231,7 +484,11 @@ func (c *CiscoTelemetryMDT) acceptTCPDialoutClients(ctx context.Context) {
 					break
 				}
 
-				c.handleTelemetry(payload.Bytes())
+				if hdr.MsgEncap == tcpEncapJSON {
+					c.handleJSONTelemetry(payload.Bytes(), connTags)
+				} else {
+					c.handleTelemetry(payload.Bytes(), connTags)
+				}
 			}
 
 			log.Printf("D! Closed Cisco MDT TCP dialout connection from %s", conn.RemoteAddr())
@@ -260,9 +517,11 @@ func (c *CiscoTelemetryMDT) acceptTCPDialoutClients(ctx context.Context) {
 
 // MdtDialout RPC server method for grpc-dialout transport
 func (c *CiscoTelemetryMDT) MdtDialout(stream dialout.GRPCMdtDialout_MdtDialoutServer) error {
-	peer, peerOK := peer.FromContext(stream.Context())
+	peerInfo, peerOK := peer.FromContext(stream.Context())
+	connTags := dialoutConnTags(peerInfo, peerOK, c.embedTags)
+
 	if peerOK {
-		log.Printf("D! Accepted Cisco MDT GRPC dialout connection from %s", peer.Addr)
+		log.Printf("D! Accepted Cisco MDT GRPC dialout connection from %s", peerInfo.Addr)
 	}
 
 	for {
@@ -279,30 +538,94 @@ func (c *CiscoTelemetryMDT) MdtDialout(stream dialout.GRPCMdtDialout_MdtDialoutS
 			break
 		}
 
-		c.handleTelemetry(packet.Data)
+		c.handleTelemetry(packet.Data, connTags)
 	}
 
 	if peerOK {
-		log.Printf("D! Closed Cisco MDT GRPC dialout connection from %s", peer.Addr)
+		log.Printf("D! Closed Cisco MDT GRPC dialout connection from %s", peerInfo.Addr)
 	}
 
 	return nil
 }
 
-// SubscribeMDTDialinDevice and extract GPB telemetry data
-func (c *CiscoTelemetryMDT) subscribeMDTDialinDevice(ctx context.Context, client *grpc.ClientConn) {
+// dialoutConnTags resolves the opt-in per-connection identity tags (see
+// embed_tags) for a single grpc-dialout stream: "source" is the peer address,
+// "source_cert_cn" is the verified client certificate CN and only populates
+// when the connection is mTLS
+func dialoutConnTags(peerInfo *peer.Peer, peerOK bool, embedTags map[string]bool) map[string]string {
+	connTags := make(map[string]string)
+	if !peerOK {
+		return connTags
+	}
+
+	if embedTags["source"] {
+		connTags["source"] = peerInfo.Addr.String()
+	}
+
+	if embedTags["source_cert_cn"] {
+		if tlsInfo, ok := peerInfo.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.PeerCertificates) > 0 {
+			connTags["source_cert_cn"] = tlsInfo.State.PeerCertificates[0].Subject.CommonName
+		}
+	}
+
+	return connTags
+}
+
+// redialBackoff tracks the exponential backoff applied between redial
+// attempts of a broken dial-in stream, so a down device isn't hammered
+type redialBackoff struct {
+	current time.Duration
+	max     time.Duration
+}
+
+func newRedialBackoff(max time.Duration) *redialBackoff {
+	if max <= 0 {
+		max = redialBackoffDefaultMax
+	}
+	return &redialBackoff{current: redialBackoffInitial, max: max}
+}
+
+// next returns the wait to use for the upcoming redial and doubles the
+// backoff (capped at max) for next time
+func (b *redialBackoff) next() time.Duration {
+	wait := b.current
+	if b.current < b.max {
+		b.current *= 2
+		if b.current > b.max {
+			b.current = b.max
+		}
+	}
+	return wait
+}
+
+// reset restores the backoff to its initial value, called after a
+// subscription survives long enough to be considered healthy
+func (b *redialBackoff) reset() {
+	b.current = redialBackoffInitial
+}
+
+// SubscribeMDTDialinDevice and extract GPB telemetry data for a single device
+func (c *CiscoTelemetryMDT) subscribeMDTDialinDevice(ctx context.Context, client *grpc.ClientConn, device CiscoMDTDevice) {
+	backoff := newRedialBackoff(device.RedialMax.Duration)
+
+	connTags := make(map[string]string)
+	if c.embedTags["source"] {
+		connTags["source"] = device.ServiceAddress
+	}
+
 	for ctx.Err() == nil {
 		request := &ems.CreateSubsArgs{
 			ReqId:    1,
 			Encode:   grpcEncodeGPBKV,
-			Subidstr: c.Subscription,
+			Subidstr: strings.Join(device.Subscriptions, ","),
 		}
-		client := ems.NewGRPCConfigOperClient(client)
-		stream, err := client.CreateSubs(ctx, request)
+		emsClient := ems.NewGRPCConfigOperClient(client)
+		stream, err := emsClient.CreateSubs(ctx, request)
 		if err != nil {
-			c.acc.AddError(fmt.Errorf("GRPC dialin subscription failed: %v", err))
+			c.acc.AddError(fmt.Errorf("GRPC dialin subscription to %s failed: %v", device.ServiceAddress, err))
 		} else {
-			log.Printf("D! Subscribed to Cisco MDT device %s", c.ServiceAddress)
+			log.Printf("D! Subscribed to Cisco MDT device %s", device.ServiceAddress)
+			subscribedAt := time.Now()
 
 			// After subscription is setup, read and handle telemetry packets
 			for ctx.Err() == nil {
@@ -312,14 +635,110 @@ func (c *CiscoTelemetryMDT) subscribeMDTDialinDevice(ctx context.Context, client
 				}
 
 				if len(packet.Errors) != 0 {
-					c.acc.AddError(fmt.Errorf("GRPC dialin error: %s", packet.Errors))
+					c.acc.AddError(fmt.Errorf("GRPC dialin error from %s: %s", device.ServiceAddress, packet.Errors))
 				} else {
-					c.handleTelemetry(packet.Data)
+					c.handleTelemetry(packet.Data, connTags)
 				}
 			}
 
 			if err != nil && err != io.EOF {
-				c.acc.AddError(fmt.Errorf("GRPC dialin subscription receive error: %v", err))
+				c.acc.AddError(fmt.Errorf("GRPC dialin subscription receive error from %s: %v", device.ServiceAddress, err))
+			}
+
+			if time.Since(subscribedAt) > redialBackoffResetAfter {
+				backoff.reset()
+			}
+
+			log.Printf("D! Connection to Cisco MDT device %s closed", device.ServiceAddress)
+		}
+
+		if device.Redial.Duration.Nanoseconds() <= 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff.next()):
+		}
+	}
+
+	client.Close()
+	c.wg.Done()
+}
+
+// SubscribeMDTDialinGNMIDevice issues a gNMI Subscribe RPC and extracts telemetry data
+func (c *CiscoTelemetryMDT) subscribeMDTDialinGNMIDevice(ctx context.Context, client *grpc.ClientConn) {
+	gnmiClient := gnmiLib.NewGNMIClient(client)
+
+	mode, ok := gnmiLib.SubscriptionList_Mode_value[strings.ToUpper(c.GNMIMode)]
+	if !ok {
+		c.acc.AddError(fmt.Errorf("invalid gNMI subscription mode: %s", c.GNMIMode))
+		client.Close()
+		c.wg.Done()
+		return
+	}
+
+	encoding, ok := gnmiLib.Encoding_value[strings.ToUpper(c.GNMIEncoding)]
+	if !ok {
+		c.acc.AddError(fmt.Errorf("invalid gNMI value encoding: %s", c.GNMIEncoding))
+		client.Close()
+		c.wg.Done()
+		return
+	}
+
+	var subscriptions []*gnmiLib.Subscription
+	for _, path := range c.GNMIPaths {
+		gpath, err := parseGNMIPath(path)
+		if err != nil {
+			c.acc.AddError(fmt.Errorf("invalid gNMI subscription path %q: %v", path, err))
+			continue
+		}
+
+		subscriptions = append(subscriptions, &gnmiLib.Subscription{
+			Path:           gpath,
+			Mode:           gnmiLib.SubscriptionMode_SAMPLE,
+			SampleInterval: uint64(c.SampleInterval.Duration.Nanoseconds()),
+		})
+	}
+
+	request := &gnmiLib.SubscribeRequest{
+		Request: &gnmiLib.SubscribeRequest_Subscribe{
+			Subscribe: &gnmiLib.SubscriptionList{
+				Mode:         gnmiLib.SubscriptionList_Mode(mode),
+				Encoding:     gnmiLib.Encoding(encoding),
+				Subscription: subscriptions,
+			},
+		},
+	}
+
+	backoff := newRedialBackoff(c.RedialMax.Duration)
+
+	for ctx.Err() == nil {
+		stream, err := gnmiClient.Subscribe(ctx)
+		if err != nil {
+			c.acc.AddError(fmt.Errorf("gNMI subscribe failed: %v", err))
+		} else if err := stream.Send(request); err != nil {
+			c.acc.AddError(fmt.Errorf("gNMI subscribe request failed: %v", err))
+		} else {
+			log.Printf("D! Subscribed to Cisco MDT device %s via gNMI", c.ServiceAddress)
+			subscribedAt := time.Now()
+
+			for ctx.Err() == nil {
+				resp, err := stream.Recv()
+				if err != nil {
+					if err != io.EOF {
+						c.acc.AddError(fmt.Errorf("gNMI dialin receive error: %v", err))
+					}
+					break
+				}
+
+				if notif := resp.GetUpdate(); notif != nil {
+					c.handleGNMINotification(notif)
+				}
+			}
+
+			if time.Since(subscribedAt) > redialBackoffResetAfter {
+				backoff.reset()
 			}
 
 			log.Printf("D! Connection to Cisco MDT device %s closed", c.ServiceAddress)
@@ -331,7 +750,7 @@ func (c *CiscoTelemetryMDT) subscribeMDTDialinDevice(ctx context.Context, client
 
 		select {
 		case <-ctx.Done():
-		case <-time.After(c.Redial.Duration):
+		case <-time.After(backoff.next()):
 		}
 	}
 
@@ -339,8 +758,195 @@ func (c *CiscoTelemetryMDT) subscribeMDTDialinDevice(ctx context.Context, client
 	c.wg.Done()
 }
 
-// Handle telemetry packet from any transport, decode and add as measurement
-func (c *CiscoTelemetryMDT) handleTelemetry(data []byte) {
+// parseGNMIPath converts a slash-separated gNMI path, with an optional
+// "origin:" prefix and "[key=value]" element selectors, into a gnmi.Path
+func parseGNMIPath(path string) (*gnmiLib.Path, error) {
+	var origin string
+	if idx := strings.Index(path, ":"); idx != -1 && !strings.Contains(path[:idx], "/") {
+		origin, path = path[:idx], path[idx+1:]
+	}
+
+	var elems []*gnmiLib.PathElem
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "" {
+			continue
+		}
+
+		name := part
+		var keys map[string]string
+		for {
+			start := strings.Index(name, "[")
+			if start == -1 {
+				break
+			}
+			end := strings.Index(name[start:], "]")
+			if end == -1 {
+				return nil, fmt.Errorf("malformed path element: %s", part)
+			}
+			end += start
+
+			kv := strings.SplitN(name[start+1:end], "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("malformed path key: %s", name[start+1:end])
+			}
+			if keys == nil {
+				keys = make(map[string]string)
+			}
+			keys[kv[0]] = kv[1]
+			name = name[:start] + name[end+1:]
+		}
+
+		elems = append(elems, &gnmiLib.PathElem{Name: name, Key: keys})
+	}
+
+	return &gnmiLib.Path{Origin: origin, Elem: elems}, nil
+}
+
+// gnmiPathToString renders a gnmi.Path as a slash-joined name, ignoring keys
+func gnmiPathToString(path *gnmiLib.Path) string {
+	var builder strings.Builder
+	for _, elem := range path.GetElem() {
+		if builder.Len() > 0 {
+			builder.WriteRune('/')
+		}
+		builder.WriteString(elem.Name)
+	}
+	return builder.String()
+}
+
+// decodeGNMIValue converts a gnmi.TypedValue into a Go value suitable for a field,
+// supporting both the JSON_IETF and PROTO (scalar) value encodings
+func decodeGNMIValue(val *gnmiLib.TypedValue) (interface{}, error) {
+	switch v := val.GetValue().(type) {
+	case *gnmiLib.TypedValue_StringVal:
+		return v.StringVal, nil
+	case *gnmiLib.TypedValue_IntVal:
+		return v.IntVal, nil
+	case *gnmiLib.TypedValue_UintVal:
+		return v.UintVal, nil
+	case *gnmiLib.TypedValue_BoolVal:
+		return v.BoolVal, nil
+	case *gnmiLib.TypedValue_BytesVal:
+		return v.BytesVal, nil
+	case *gnmiLib.TypedValue_FloatVal:
+		return v.FloatVal, nil
+	case *gnmiLib.TypedValue_DecimalVal:
+		return float64(v.DecimalVal.Digits) / math.Pow10(int(v.DecimalVal.Precision)), nil
+	case *gnmiLib.TypedValue_JsonIetfVal:
+		var out interface{}
+		if err := json.Unmarshal(v.JsonIetfVal, &out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	case *gnmiLib.TypedValue_JsonVal:
+		var out interface{}
+		if err := json.Unmarshal(v.JsonVal, &out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	case *gnmiLib.TypedValue_ProtoBytes:
+		return v.ProtoBytes, nil
+	default:
+		return nil, fmt.Errorf("unsupported gNMI value type %T", v)
+	}
+}
+
+// handleGNMINotification decodes a gNMI Notification into measurement points:
+// the prefix names the measurement and supplies tags from its key elements,
+// and every Update becomes one field on the point for its resolved tag set
+// (prefix keys plus the update path's own keys), so one notification yields
+// one point per distinct keyed instance rather than one measurement per Update
+func (c *CiscoTelemetryMDT) handleGNMINotification(notif *gnmiLib.Notification) {
+	timestamp := time.Unix(0, notif.Timestamp)
+
+	measurement := gnmiPathToString(notif.Prefix)
+	if measurement == "" {
+		measurement = "gnmi"
+	}
+
+	prefixTags := make(map[string]string)
+	for _, elem := range notif.Prefix.GetElem() {
+		for k, v := range elem.Key {
+			prefixTags[k] = v
+		}
+	}
+
+	// Updates are grouped by their fully resolved tag set rather than
+	// batched into one shared point: a wildcarded subscription (e.g.
+	// interfaces/interface[name=*]/state/counters/in-octets) or an initial
+	// sync burst can pack updates for several distinct keyed list instances
+	// into one Notification, and those must stay separate points or one
+	// instance's fields/tags overwrite another's
+	type gnmiGroup struct {
+		tags   map[string]string
+		fields map[string]interface{}
+	}
+	groups := make(map[string]*gnmiGroup)
+	var order []string
+
+	for _, update := range notif.Update {
+		name := gnmiPathToString(update.Path)
+		if name == "" {
+			c.acc.AddError(fmt.Errorf("gNMI update with empty path"))
+			continue
+		}
+
+		value, err := decodeGNMIValue(update.Val)
+		if err != nil {
+			c.acc.AddError(fmt.Errorf("gNMI decode error: %v", err))
+			continue
+		}
+
+		tags := make(map[string]string, len(prefixTags))
+		for k, v := range prefixTags {
+			tags[k] = v
+		}
+		for _, elem := range update.Path.GetElem() {
+			for k, v := range elem.Key {
+				tags[k] = v
+			}
+		}
+
+		key := gnmiTagsKey(tags)
+		g, ok := groups[key]
+		if !ok {
+			g = &gnmiGroup{tags: tags, fields: make(map[string]interface{})}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.fields[name] = value
+	}
+
+	for _, key := range order {
+		g := groups[key]
+		c.acc.AddFields(measurement, g.fields, g.tags, timestamp)
+	}
+}
+
+// gnmiTagsKey renders a tag set as a canonical, sorted string so two updates
+// carrying the same key elements (in any insertion order) are recognised as
+// the same keyed list instance and batched into the same point
+func gnmiTagsKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// Handle telemetry packet from any transport, decode and add as measurement.
+// connTags carries the opt-in per-connection/per-device identity tags (see
+// embed_tags) and is merged into every emitted metric's tags as-is
+func (c *CiscoTelemetryMDT) handleTelemetry(data []byte, connTags map[string]string) {
 	var namebuf bytes.Buffer
 	telemetry := &telemetry.Telemetry{}
 	err := proto.Unmarshal(data, telemetry)
@@ -349,6 +955,18 @@ func (c *CiscoTelemetryMDT) handleTelemetry(data []byte) {
 		return
 	}
 
+	// Resolved once per message rather than once per leaf
+	sub := c.subscriptions[telemetry.EncodingPath]
+	measurement := telemetry.EncodingPath
+	if sub != nil && sub.name != "" {
+		measurement = sub.name
+	}
+
+	if len(telemetry.DataGpbkv) == 0 && telemetry.DataGpb != nil && len(telemetry.DataGpb.Row) > 0 {
+		c.handleGPBCompactTelemetry(telemetry, measurement, connTags, sub)
+		return
+	}
+
 	for _, gpbkv := range telemetry.DataGpbkv {
 		var fields map[string]interface{}
 
@@ -367,16 +985,19 @@ func (c *CiscoTelemetryMDT) handleTelemetry(data []byte) {
 		for _, field := range gpbkv.Fields {
 			switch field.Name {
 			case "keys":
-				tags = make(map[string]string, len(field.Fields)+2)
+				tags = make(map[string]string, len(field.Fields)+3)
 				tags["Producer"] = telemetry.GetNodeIdStr()
 				tags["Target"] = telemetry.GetSubscriptionIdStr()
+				for tag, value := range connTags {
+					tags[tag] = value
+				}
 				for _, subfield := range field.Fields {
-					c.parseGPBKVField(subfield, &namebuf, telemetry.EncodingPath, timestamp, tags, nil)
+					parseGPBKVField(subfield, &namebuf, tags, nil, sub)
 				}
 			case "content":
 				fields = make(map[string]interface{}, len(field.Fields))
 				for _, subfield := range field.Fields {
-					c.parseGPBKVField(subfield, &namebuf, telemetry.EncodingPath, timestamp, tags, fields)
+					parseGPBKVField(subfield, &namebuf, tags, fields, sub)
 				}
 			default:
 				log.Printf("I! Unexpected top-level MDT field: %s", field.Name)
@@ -385,7 +1006,7 @@ func (c *CiscoTelemetryMDT) handleTelemetry(data []byte) {
 
 		// Emit measurement
 		if len(fields) > 0 && len(tags) > 0 && len(telemetry.EncodingPath) > 0 {
-			c.acc.AddFields(telemetry.EncodingPath, fields, tags, timestamp)
+			c.acc.AddFields(measurement, fields, tags, timestamp)
 		} else {
 			c.acc.AddError(fmt.Errorf("Cisco MDT invalid field: encoding path or measurement empty"))
 		}
@@ -393,15 +1014,350 @@ func (c *CiscoTelemetryMDT) handleTelemetry(data []byte) {
 
 }
 
-// Recursively parse GPBKV field structure into fields or tags
-func (c *CiscoTelemetryMDT) parseGPBKVField(field *telemetry.TelemetryField, namebuf *bytes.Buffer,
-	path string, timestamp time.Time, tags map[string]string, fields map[string]interface{}) {
+// loadGPBCompactDescriptors compiles the encoding_paths config into message
+// descriptors used to decode compact-GPB (telemetry.DataGpb) rows
+func (c *CiscoTelemetryMDT) loadGPBCompactDescriptors() error {
+	if len(c.EncodingPaths) == 0 {
+		return nil
+	}
+
+	c.gpbCompactDescriptors = make(map[string]protoreflect.MessageDescriptor, len(c.EncodingPaths))
+	for path, cfg := range c.EncodingPaths {
+		descriptor, err := loadGPBCompactDescriptor(cfg.DescriptorFile, cfg.MessageName)
+		if err != nil {
+			return fmt.Errorf("failed to load GPB-compact descriptor for encoding path %q: %v", path, err)
+		}
+		c.gpbCompactDescriptors[path] = descriptor
+	}
+
+	return nil
+}
+
+// loadEmbedTags compiles the embed_tags opt-in list into a lookup set
+func (c *CiscoTelemetryMDT) loadEmbedTags() {
+	c.embedTags = make(map[string]bool, len(c.EmbedTags))
+	for _, tag := range c.EmbedTags {
+		c.embedTags[tag] = true
+	}
+}
+
+// loadSubscriptions compiles the [[inputs.cisco_telemetry_mdt.subscription]]
+// blocks into a lookup by encoding_path, resolved once here rather than once
+// per leaf in the hot decode path
+func (c *CiscoTelemetryMDT) loadSubscriptions() error {
+	if len(c.Subscriptions) == 0 {
+		return nil
+	}
+
+	c.subscriptions = make(map[string]*resolvedSubscription, len(c.Subscriptions))
+	for _, sub := range c.Subscriptions {
+		resolved := &resolvedSubscription{name: sub.Name}
+
+		if len(sub.TagFields) > 0 {
+			resolved.tagFields = make(map[string]bool, len(sub.TagFields))
+			for _, field := range sub.TagFields {
+				resolved.tagFields[field] = true
+			}
+		}
+
+		if len(sub.FieldExclude) > 0 {
+			f, err := filter.Compile(sub.FieldExclude)
+			if err != nil {
+				return fmt.Errorf("invalid field_exclude for encoding path %q: %v", sub.EncodingPath, err)
+			}
+			resolved.fieldExclude = f
+		}
+
+		if len(sub.FieldStrings) > 0 {
+			resolved.fieldStrings = make(map[string]bool, len(sub.FieldStrings))
+			for _, field := range sub.FieldStrings {
+				resolved.fieldStrings[field] = true
+			}
+		}
+
+		c.subscriptions[sub.EncodingPath] = resolved
+	}
+
+	return nil
+}
+
+// loadGPBCompactDescriptor reads a compiled FileDescriptorSet (as produced by
+// `protoc --descriptor_set_out`) and returns the message descriptor named by
+// messageName (its fully-qualified "package.Message" name). If messageName
+// is empty, the set must contain exactly one message: with more than one,
+// guessing which one matches Row.Content would let dynamicpb silently decode
+// against the wrong schema instead of erroring
+func loadGPBCompactDescriptor(file, messageName string) (protoreflect.MessageDescriptor, error) {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	fdset := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(raw, fdset); err != nil {
+		return nil, err
+	}
+
+	files, err := protodesc.NewFiles(fdset)
+	if err != nil {
+		return nil, err
+	}
+
+	if messageName != "" {
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+		if err != nil {
+			return nil, fmt.Errorf("message %q not found in %s: %v", messageName, file, err)
+		}
+		message, ok := descriptor.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%q in %s is not a message", messageName, file)
+		}
+		return message, nil
+	}
+
+	var message protoreflect.MessageDescriptor
+	count := 0
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		messages := fd.Messages()
+		for i := 0; i < messages.Len(); i++ {
+			message = messages.Get(i)
+			count++
+		}
+		return true
+	})
+
+	switch {
+	case count == 0:
+		return nil, fmt.Errorf("no message descriptor found in %s", file)
+	case count > 1:
+		return nil, fmt.Errorf("%s defines %d messages; set message_name to disambiguate", file, count)
+	}
+
+	return message, nil
+}
+
+// handleGPBCompactTelemetry decodes a compact-GPB Telemetry message (rows of
+// schema-less bytes) using the descriptor configured for its encoding_path.
+// sub, when non-nil, applies the same tag_fields/field_exclude/field_strings
+// mapping as the GPBKV path (parseGPBKVField), resolved once per message
+func (c *CiscoTelemetryMDT) handleGPBCompactTelemetry(telemetry *telemetry.Telemetry, measurement string, connTags map[string]string, sub *resolvedSubscription) {
+	descriptor, ok := c.gpbCompactDescriptors[telemetry.EncodingPath]
+	if !ok {
+		c.acc.AddError(fmt.Errorf("Cisco MDT: no encoding_paths descriptor configured for compact GPB path %q", telemetry.EncodingPath))
+		return
+	}
+
+	for _, row := range telemetry.DataGpb.Row {
+		message := dynamicpb.NewMessage(descriptor)
+		if err := proto.Unmarshal(row.Content, message); err != nil {
+			c.acc.AddError(fmt.Errorf("Cisco MDT failed to decode GPB-compact row for %q: %v", telemetry.EncodingPath, err))
+			continue
+		}
+
+		measured := row.Timestamp
+		if measured == 0 {
+			measured = telemetry.MsgTimestamp
+		}
+		timestamp := time.Unix(int64(measured/1000), int64(measured%1000)*1000000)
+
+		tags := make(map[string]string, 3)
+		tags["Producer"] = telemetry.GetNodeIdStr()
+		tags["Target"] = telemetry.GetSubscriptionIdStr()
+		for tag, value := range connTags {
+			tags[tag] = value
+		}
+
+		fields := make(map[string]interface{})
+		var namebuf bytes.Buffer
+		parseDynamicMessage(message, &namebuf, tags, fields, sub)
+
+		if len(fields) > 0 {
+			c.acc.AddFields(measurement, fields, tags, timestamp)
+		}
+	}
+}
+
+// parseDynamicMessage recursively flattens a dynamicpb message into fields,
+// slash-joining nested message and list names the same way parseGPBKVField
+// does. sub, when non-nil, applies the same tag_fields/field_exclude/
+// field_strings mapping as the GPBKV path for that encoding_path.
+func parseDynamicMessage(message *dynamicpb.Message, namebuf *bytes.Buffer, tags map[string]string, fields map[string]interface{}, sub *resolvedSubscription) {
+	message.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		namelen := namebuf.Len()
+		if namelen > 0 {
+			namebuf.WriteRune('/')
+		}
+		namebuf.WriteString(string(fd.Name()))
+		name := namebuf.String()
+
+		if sub != nil && sub.fieldExclude != nil && sub.fieldExclude.Match(name) {
+			namebuf.Truncate(namelen)
+			return true
+		}
+
+		switch {
+		case fd.Kind() == protoreflect.MessageKind && fd.IsList():
+			// Repeated submessage (common for Cisco counter tables): recurse
+			// per element instead of handing a *dynamicpb.Message to AddFields
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				elemlen := namebuf.Len()
+				namebuf.WriteString(fmt.Sprintf("/%d", i))
+				parseDynamicMessage(list.Get(i).Message().Interface().(*dynamicpb.Message), namebuf, tags, fields, sub)
+				namebuf.Truncate(elemlen)
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			parseDynamicMessage(v.Message().Interface().(*dynamicpb.Message), namebuf, tags, fields, sub)
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				fields[fmt.Sprintf("%s/%d", name, i)] = list.Get(i).Interface()
+			}
+		case sub != nil && sub.tagFields[name]:
+			// Leaf promoted to a tag via subscription config
+			tags[name] = fmt.Sprint(v.Interface())
+		case sub != nil && sub.fieldStrings[name]:
+			// Leaf forced to a string (e.g. MAC addresses, prefixes)
+			fields[name] = formatFieldString(v.Interface())
+		default:
+			fields[name] = v.Interface()
+		}
+
+		namebuf.Truncate(namelen)
+		return true
+	})
+}
+
+// handleJSONTelemetry decodes a JSON-encoded Telemetry payload (as emitted by
+// NX-OS and IOS XE when the subscription's msg_encap is JSON rather than GPB).
+// JSON encap is only seen on tcp-dialout, so connTags carries the opt-in
+// per-connection identity tags (see embed_tags) rather than a device source
+func (c *CiscoTelemetryMDT) handleJSONTelemetry(data []byte, connTags map[string]string) {
+	var msg jsonTelemetry
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.acc.AddError(fmt.Errorf("Cisco MDT failed to decode JSON telemetry: %v", err))
+		return
+	}
+
+	// Resolved once per message rather than once per leaf
+	sub := c.subscriptions[msg.EncodingPath]
+	measurement := msg.EncodingPath
+	if sub != nil && sub.name != "" {
+		measurement = sub.name
+	}
+
+	for _, row := range msg.DataJSON {
+		measured := row.Timestamp
+		if measured == 0 {
+			measured = msg.MsgTimestamp
+		}
+		timestamp := time.Unix(int64(measured/1000), int64(measured%1000)*1000000)
+
+		tags := make(map[string]string, len(row.Keys)+3)
+		tags["Producer"] = msg.NodeIDStr
+		tags["Target"] = msg.SubscriptionIDStr
+		for tag, value := range connTags {
+			tags[tag] = value
+		}
+
+		var namebuf bytes.Buffer
+		for name, value := range row.Keys {
+			flattenJSONField(name, value, &namebuf, tags, nil, sub)
+		}
+
+		fields := make(map[string]interface{}, len(row.Content))
+		for name, value := range row.Content {
+			flattenJSONField(name, value, &namebuf, tags, fields, sub)
+		}
+
+		if len(fields) > 0 && len(msg.EncodingPath) > 0 {
+			c.acc.AddFields(measurement, fields, tags, timestamp)
+		} else {
+			c.acc.AddError(fmt.Errorf("Cisco MDT invalid JSON telemetry: encoding path or measurement empty"))
+		}
+	}
+}
+
+// jsonTelemetry mirrors the GPBKV Telemetry message for the JSON encoding
+type jsonTelemetry struct {
+	NodeIDStr         string             `json:"node_id_str"`
+	SubscriptionIDStr string             `json:"subscription_id_str"`
+	EncodingPath      string             `json:"encoding_path"`
+	MsgTimestamp      uint64             `json:"msg_timestamp"`
+	DataJSON          []jsonTelemetryRow `json:"data_json"`
+}
+
+type jsonTelemetryRow struct {
+	Timestamp uint64                 `json:"timestamp"`
+	Keys      map[string]interface{} `json:"keys"`
+	Content   map[string]interface{} `json:"content"`
+}
+
+// flattenJSONField recursively parses a JSON keys/content field into tags or
+// fields, slash-joining nested object and array names. sub, when non-nil, is
+// the resolved subscription config for this message's encoding path and can
+// promote a content leaf to a tag, emit its value as a string, or drop its whole
+// subtree, the same as parseGPBKVField/parseDynamicMessage do for the other
+// telemetry encodings
+func flattenJSONField(name string, value interface{}, namebuf *bytes.Buffer, tags map[string]string, fields map[string]interface{}, sub *resolvedSubscription) {
+	namelen := namebuf.Len()
+	if namelen > 0 {
+		namebuf.WriteRune('/')
+	}
+	namebuf.WriteString(name)
+	fullname := namebuf.String()
+
+	if fields != nil && sub != nil && sub.fieldExclude != nil && sub.fieldExclude.Match(fullname) {
+		namebuf.Truncate(namelen)
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for subname, subvalue := range v {
+			flattenJSONField(subname, subvalue, namebuf, tags, fields, sub)
+		}
+	case []interface{}:
+		for i, subvalue := range v {
+			flattenJSONField(fmt.Sprintf("%d", i), subvalue, namebuf, tags, fields, sub)
+		}
+	default:
+		switch {
+		case fields == nil:
+			// Everything under "keys" is a tag
+			tags[fullname] = fmt.Sprint(v)
+		case sub != nil && sub.tagFields[fullname]:
+			// Content leaf promoted to a tag via subscription config
+			tags[fullname] = fmt.Sprint(v)
+		case sub != nil && sub.fieldStrings[fullname]:
+			// Content leaf forced to a string (e.g. MAC addresses, prefixes)
+			fields[fullname] = formatFieldString(v)
+		default:
+			fields[fullname] = v
+		}
+	}
+
+	namebuf.Truncate(namelen)
+}
+
+// Recursively parse GPBKV field structure into fields or tags. sub, when
+// non-nil, is the resolved subscription config for this message's encoding
+// path and can promote a content leaf to a tag, emit its value as a string, or
+// drop its whole subtree.
+func parseGPBKVField(field *telemetry.TelemetryField, namebuf *bytes.Buffer,
+	tags map[string]string, fields map[string]interface{}, sub *resolvedSubscription) {
 
 	namelen := namebuf.Len()
 	if namelen > 0 {
 		namebuf.WriteRune('/')
 	}
 	namebuf.WriteString(field.Name)
+	name := namebuf.String()
+
+	if fields != nil && sub != nil && sub.fieldExclude != nil && sub.fieldExclude.Match(name) {
+		namebuf.Truncate(namelen)
+		return
+	}
 
 	// Decode Telemetry field value if set
 	var value interface{}
@@ -427,21 +1383,45 @@ func (c *CiscoTelemetryMDT) parseGPBKVField(field *telemetry.TelemetryField, nam
 	}
 
 	if value != nil {
-		// Distinguish between tags (keys) and fields (data) to write to
-		if fields != nil {
-			fields[namebuf.String()] = value
-		} else {
-			tags[namebuf.String()] = fmt.Sprint(value)
+		switch {
+		case fields == nil:
+			// Everything under "keys" is a tag
+			tags[name] = fmt.Sprint(value)
+		case sub != nil && sub.tagFields[name]:
+			// Content leaf promoted to a tag via subscription config
+			tags[name] = fmt.Sprint(value)
+		case sub != nil && sub.fieldStrings[name]:
+			// Content leaf forced to a string (e.g. MAC addresses, prefixes)
+			fields[name] = formatFieldString(value)
+		default:
+			fields[name] = value
 		}
 	}
 
 	for _, subfield := range field.Fields {
-		c.parseGPBKVField(subfield, namebuf, path, timestamp, tags, fields)
+		parseGPBKVField(subfield, namebuf, tags, fields, sub)
 	}
 
 	namebuf.Truncate(namelen)
 }
 
+// formatFieldString renders a leaf value forced to a string by field_strings.
+// Byte slices (the typical Cisco encoding for MAC addresses and similar
+// binary identifiers) are hex/colon-joined; fmt.Sprint would otherwise print
+// Go's "[0 1 2 ...]" slice representation instead of a MAC-style string
+func formatFieldString(value interface{}) string {
+	b, ok := value.([]byte)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+
+	parts := make([]string, len(b))
+	for i, octet := range b {
+		parts[i] = fmt.Sprintf("%02x", octet)
+	}
+	return strings.Join(parts, ":")
+}
+
 // Stop listener and cleanup
 func (c *CiscoTelemetryMDT) Stop() {
 	c.cancel()
@@ -458,7 +1438,7 @@ func (c *CiscoTelemetryMDT) Stop() {
 }
 
 const sampleConfig = `
-  ## Telemetry transport (one of: tcp-dialout, grpc-dialout, grpc-dialin)
+  ## Telemetry transport (one of: tcp-dialout, grpc-dialout, grpc-dialin, grpc-gnmi)
   transport = "grpc-dialout"
 
   ## Address and port to host telemetry listener on (dialout) or address to connect to (dialin)
@@ -467,17 +1447,46 @@ const sampleConfig = `
   ## Enable TLS for transport
   # enable_tls = true
 
-  ## grpc-dialin: define credentials and subscription
+  ## grpc-dialin: one [[inputs.cisco_telemetry_mdt.devices]] sub-table per
+  ## device lets a single plugin instance scrape a fleet of routers, each
+  ## with its own credentials, subscription and TLS settings. With embed_tags
+  ## set to include "source" (see below), metrics are tagged with "source"
+  ## set to the device's service_address.
+  # [[inputs.cisco_telemetry_mdt.devices]]
+  #   service_address = "10.0.0.1:57400"
+  #   username = "cisco"
+  #   password = "cisco"
+  #   subscription = ["subscription"]
+  #   redial = "10s"
+  #   # redial backs off exponentially (500ms, 1s, 2s, ...) after each broken
+  #   # stream, capped at redial_max, and resets once a stream survives a minute
+  #   # redial_max = "1m"
+  #   # keepalive_time = "10s"
+  #   # keepalive_timeout = "5s"
+  #   # permit_without_stream = true
+  #   # tls_ca = "/etc/telegraf/ca.pem"
+  #   # tls_cert = "/etc/telegraf/cert.pem"
+  #   # tls_key = "/etc/telegraf/key.pem"
+  #   # insecure_skip_verify = true
+
+  ## grpc-gnmi: define credentials and the paths to subscribe to
   # username = "cisco"
   # password = "cisco"
-  # subscription = "subscription"
+  # gnmi_paths = ["openconfig-interfaces:interfaces/interface/state/counters"]
+  # gnmi_mode = "stream"
+  # gnmi_encoding = "json_ietf"
+  # sample_interval = "10s"
   # redial = "10s"
+  # redial_max = "1m"
+  # keepalive_time = "10s"
+  # keepalive_timeout = "5s"
+  # permit_without_stream = true
 
-  ## grpc-dialin: define TLS CA to authenticate the device
+  ## grpc-gnmi: define TLS CA to authenticate the device
   # tls_ca = "/etc/telegraf/ca.pem"
   # insecure_skip_verify = true
 
-  ## grpc-dialin: define client-side TLS certificate & key to authenticate to the device
+  ## grpc-gnmi: define client-side TLS certificate & key to authenticate to the device
   # tls_cert = "/etc/telegraf/cert.pem"
   # tls_key = "/etc/telegraf/key.pem"
 
@@ -488,6 +1497,44 @@ const sampleConfig = `
 
   ## grpc-dialout: enable TLS client authentication and define allowed CA certificates
   # tls_allowed_cacerts = ["/etc/telegraf/clientca.pem"]
+
+  ## grpc-dialout: reject long-idle connections from routers behind stateful
+  ## middleboxes, instead of leaving MdtDialout's stream.Recv() blocked forever
+  # keepalive_min_time = "5m"
+  # keepalive_permit_without_stream = false
+  # keepalive_max_connection_idle = "15m"
+
+  ## Opt in to per-device/per-connection identity tags so a fleet/multi-router
+  ## deployment can tell samples apart without relying on NodeIdStr, which
+  ## routers frequently misconfigure. "source" is the grpc-dialin device's
+  ## service_address, or the tcp-dialout/grpc-dialout peer address;
+  ## "source_cert_cn" is the verified client certificate CN and only applies
+  ## to grpc-dialout with tls_allowed_cacerts set. Off by default so
+  ## upgrading does not change the series of existing dashboards.
+  # embed_tags = ["source", "source_cert_cn"]
+
+  ## Decode compact-GPB (telemetry.DataGpb) sensor paths, which are more
+  ## bandwidth-efficient than GPBKV, by mapping each subscribed encoding_path
+  ## to a FileDescriptorSet compiled with: protoc -o path.desc path.proto
+  ## message_name is required whenever descriptor_file describes more than
+  ## one message, so Row.Content can't be decoded against the wrong schema
+  # [inputs.cisco_telemetry_mdt.encoding_paths."Cisco-IOS-XR-infra-statsd-oper:infrastructure-statistics/interfaces/interface/latest/generic-counters"]
+  #   descriptor_file = "/etc/telegraf/generic-counters.desc"
+  #   message_name = "GenericCounters"
+
+  ## tcp-dialout: JSON-encoded telemetry (msg_encap = 2 in the dialout header)
+  ## is decoded automatically alongside GPB; no additional configuration needed
+
+  ## Customize how an encoding_path's GPBKV, compact-GPB or JSON leaves are emitted, instead of the
+  ## default "rename nothing, tag nothing extra, slash-join every name" rule
+  # [[inputs.cisco_telemetry_mdt.subscription]]
+  #   encoding_path = "Cisco-IOS-XR-infra-statsd-oper:infrastructure-statistics/interfaces/interface/latest/generic-counters"
+  #   name = "ios_xr_interface_counters"
+  #   tag_fields = ["interface-name"]
+  #   field_exclude = ["*/bytes-sent-high-word", "*/bytes-received-high-word"]
+  #   field_strings = ["mac-address"]
+  ## field_exclude takes glob patterns; tag_fields and field_strings match the
+  ## exact slash-joined leaf name.
 `
 
 // SampleConfig of plugin
@@ -511,6 +1558,9 @@ func init() {
 			Transport:      "grpc-dialout",
 			ServiceAddress: ":57000",
 			Redial:         internal.Duration{Duration: 10 * time.Second},
+			GNMIMode:       "stream",
+			GNMIEncoding:   "json_ietf",
+			SampleInterval: internal.Duration{Duration: 10 * time.Second},
 		}
 	})
 }
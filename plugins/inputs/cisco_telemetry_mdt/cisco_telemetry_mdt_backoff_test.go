@@ -0,0 +1,43 @@
+package cisco_telemetry_mdt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRedialBackoffDefaultMax(t *testing.T) {
+	b := newRedialBackoff(0)
+	if b.max != redialBackoffDefaultMax {
+		t.Errorf("max = %v, want default %v", b.max, redialBackoffDefaultMax)
+	}
+	if b.current != redialBackoffInitial {
+		t.Errorf("current = %v, want initial %v", b.current, redialBackoffInitial)
+	}
+}
+
+func TestRedialBackoffNextDoublesAndCaps(t *testing.T) {
+	b := newRedialBackoff(2 * time.Second)
+
+	want := []time.Duration{
+		redialBackoffInitial,
+		redialBackoffInitial * 2,
+		2 * time.Second,
+		2 * time.Second,
+	}
+
+	for i, w := range want {
+		if got := b.next(); got != w {
+			t.Errorf("next() call %d = %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestRedialBackoffReset(t *testing.T) {
+	b := newRedialBackoff(time.Minute)
+	b.next()
+	b.next()
+	b.reset()
+	if b.current != redialBackoffInitial {
+		t.Errorf("current after reset = %v, want %v", b.current, redialBackoffInitial)
+	}
+}
@@ -0,0 +1,141 @@
+package cisco_telemetry_mdt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/telegraf/filter"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// buildTestDescriptor assembles a tiny "Counter"/"Row" message pair in
+// memory, the same way loadGPBCompactDescriptor would from a descriptor_file
+// on disk, so parseDynamicMessage can be exercised without a real .proto build
+func buildTestDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+
+	fdproto := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Counter"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("value"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_UINT64.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						JsonName: proto.String("value"),
+					},
+				},
+			},
+			{
+				Name: proto.String("Row"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("name"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						JsonName: proto.String("name"),
+					},
+					{
+						Name:     proto.String("counters"),
+						Number:   proto.Int32(2),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+						TypeName: proto.String(".test.Counter"),
+						JsonName: proto.String("counters"),
+					},
+				},
+			},
+		},
+	}
+
+	fdset := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdproto}}
+	files, err := protodesc.NewFiles(fdset)
+	if err != nil {
+		t.Fatalf("protodesc.NewFiles: %v", err)
+	}
+
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName("test.Row"))
+	if err != nil {
+		t.Fatalf("FindDescriptorByName: %v", err)
+	}
+	return descriptor.(protoreflect.MessageDescriptor)
+}
+
+func TestParseDynamicMessageRepeatedSubmessage(t *testing.T) {
+	descriptor := buildTestDescriptor(t)
+	message := dynamicpb.NewMessage(descriptor)
+
+	message.Set(descriptor.Fields().ByName("name"), protoreflect.ValueOfString("eth0"))
+
+	counterDescriptor := descriptor.Fields().ByName("counters").Message()
+	counters := message.Mutable(descriptor.Fields().ByName("counters")).List()
+	for _, v := range []uint64{10, 20} {
+		counter := dynamicpb.NewMessage(counterDescriptor)
+		counter.Set(counterDescriptor.Fields().ByName("value"), protoreflect.ValueOfUint64(v))
+		counters.Append(protoreflect.ValueOfMessage(counter))
+	}
+
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	var namebuf bytes.Buffer
+	parseDynamicMessage(message, &namebuf, tags, fields, nil)
+
+	want := map[string]interface{}{
+		"name":             "eth0",
+		"counters/0/value": uint64(10),
+		"counters/1/value": uint64(20),
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %#v, want %#v", fields, want)
+	}
+}
+
+func TestParseDynamicMessageTagField(t *testing.T) {
+	descriptor := buildTestDescriptor(t)
+	message := dynamicpb.NewMessage(descriptor)
+	message.Set(descriptor.Fields().ByName("name"), protoreflect.ValueOfString("eth0"))
+
+	sub := &resolvedSubscription{tagFields: map[string]bool{"name": true}}
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	var namebuf bytes.Buffer
+	parseDynamicMessage(message, &namebuf, tags, fields, sub)
+
+	if tags["name"] != "eth0" {
+		t.Errorf("tags[name] = %q, want eth0", tags["name"])
+	}
+	if _, ok := fields["name"]; ok {
+		t.Errorf("name promoted to tag should not also appear in fields")
+	}
+}
+
+func TestParseDynamicMessageFieldExclude(t *testing.T) {
+	descriptor := buildTestDescriptor(t)
+	message := dynamicpb.NewMessage(descriptor)
+	message.Set(descriptor.Fields().ByName("name"), protoreflect.ValueOfString("eth0"))
+
+	f, err := filter.Compile([]string{"name"})
+	if err != nil {
+		t.Fatalf("filter.Compile: %v", err)
+	}
+	sub := &resolvedSubscription{fieldExclude: f}
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	var namebuf bytes.Buffer
+	parseDynamicMessage(message, &namebuf, tags, fields, sub)
+
+	if _, ok := fields["name"]; ok {
+		t.Errorf("excluded field %q should not appear in fields", "name")
+	}
+}
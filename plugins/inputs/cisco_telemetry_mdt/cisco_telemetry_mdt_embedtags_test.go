@@ -0,0 +1,96 @@
+package cisco_telemetry_mdt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestLoadEmbedTags(t *testing.T) {
+	c := &CiscoTelemetryMDT{EmbedTags: []string{"source", "source_cert_cn"}}
+	c.loadEmbedTags()
+
+	want := map[string]bool{"source": true, "source_cert_cn": true}
+	if !reflect.DeepEqual(c.embedTags, want) {
+		t.Errorf("embedTags = %#v, want %#v", c.embedTags, want)
+	}
+}
+
+func TestLoadEmbedTagsEmpty(t *testing.T) {
+	c := &CiscoTelemetryMDT{}
+	c.loadEmbedTags()
+
+	if len(c.embedTags) != 0 {
+		t.Errorf("embedTags = %#v, want empty", c.embedTags)
+	}
+}
+
+func testPeer(cn string) *peer.Peer {
+	var authInfo credentials.AuthInfo
+	if cn != "" {
+		authInfo = credentials.TLSInfo{
+			State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{
+					{Subject: pkix.Name{CommonName: cn}},
+				},
+			},
+		}
+	}
+
+	return &peer.Peer{
+		Addr:     &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 57000},
+		AuthInfo: authInfo,
+	}
+}
+
+func TestDialoutConnTagsSource(t *testing.T) {
+	p := testPeer("")
+	tags := dialoutConnTags(p, true, map[string]bool{"source": true})
+
+	want := map[string]string{"source": "192.0.2.1:57000"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %#v, want %#v", tags, want)
+	}
+}
+
+func TestDialoutConnTagsSourceCertCN(t *testing.T) {
+	p := testPeer("router1.example.com")
+	tags := dialoutConnTags(p, true, map[string]bool{"source_cert_cn": true})
+
+	want := map[string]string{"source_cert_cn": "router1.example.com"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %#v, want %#v", tags, want)
+	}
+}
+
+func TestDialoutConnTagsSourceCertCNWithoutTLS(t *testing.T) {
+	p := testPeer("")
+	tags := dialoutConnTags(p, true, map[string]bool{"source_cert_cn": true})
+
+	if len(tags) != 0 {
+		t.Errorf("tags = %#v, want empty without a TLS peer", tags)
+	}
+}
+
+func TestDialoutConnTagsNoEmbedTags(t *testing.T) {
+	p := testPeer("router1.example.com")
+	tags := dialoutConnTags(p, true, nil)
+
+	if len(tags) != 0 {
+		t.Errorf("tags = %#v, want empty when embed_tags is unset", tags)
+	}
+}
+
+func TestDialoutConnTagsPeerUnavailable(t *testing.T) {
+	tags := dialoutConnTags(nil, false, map[string]bool{"source": true, "source_cert_cn": true})
+
+	if len(tags) != 0 {
+		t.Errorf("tags = %#v, want empty when peer info is unavailable", tags)
+	}
+}
@@ -0,0 +1,180 @@
+package cisco_telemetry_mdt
+
+import (
+	"reflect"
+	"testing"
+
+	gnmiLib "github.com/openconfig/gnmi/proto/gnmi"
+
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestParseGNMIPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		origin string
+		elems  []string
+	}{
+		{name: "simple", path: "interfaces/interface/state/counters",
+			elems: []string{"interfaces", "interface", "state", "counters"}},
+		{name: "with origin", path: "openconfig-interfaces:interfaces/interface",
+			origin: "openconfig-interfaces", elems: []string{"interfaces", "interface"}},
+		{name: "with key selector", path: "interfaces/interface[name=eth0]/state",
+			elems: []string{"interfaces", "interface", "state"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGNMIPath(tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Origin != tt.origin {
+				t.Errorf("origin = %q, want %q", got.Origin, tt.origin)
+			}
+			if len(got.Elem) != len(tt.elems) {
+				t.Fatalf("got %d elems, want %d", len(got.Elem), len(tt.elems))
+			}
+			for i, elem := range got.Elem {
+				if elem.Name != tt.elems[i] {
+					t.Errorf("elem[%d].Name = %q, want %q", i, elem.Name, tt.elems[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseGNMIPathKeySelector(t *testing.T) {
+	got, err := parseGNMIPath("interfaces/interface[name=eth0]/state")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Elem[1].Key["name"] != "eth0" {
+		t.Errorf("key name = %q, want eth0", got.Elem[1].Key["name"])
+	}
+}
+
+func TestParseGNMIPathMalformedKey(t *testing.T) {
+	if _, err := parseGNMIPath("interface[name"); err == nil {
+		t.Fatal("expected error for unterminated key selector, got nil")
+	}
+}
+
+func TestGNMIPathToString(t *testing.T) {
+	path := &gnmiLib.Path{
+		Elem: []*gnmiLib.PathElem{
+			{Name: "interfaces"},
+			{Name: "interface"},
+		},
+	}
+	if got := gnmiPathToString(path); got != "interfaces/interface" {
+		t.Errorf("gnmiPathToString = %q, want %q", got, "interfaces/interface")
+	}
+}
+
+func TestGNMIPathToStringNil(t *testing.T) {
+	if got := gnmiPathToString(nil); got != "" {
+		t.Errorf("gnmiPathToString(nil) = %q, want empty", got)
+	}
+}
+
+func TestDecodeGNMIValue(t *testing.T) {
+	tests := []struct {
+		name string
+		val  *gnmiLib.TypedValue
+		want interface{}
+	}{
+		{name: "string", val: &gnmiLib.TypedValue{Value: &gnmiLib.TypedValue_StringVal{StringVal: "up"}}, want: "up"},
+		{name: "int", val: &gnmiLib.TypedValue{Value: &gnmiLib.TypedValue_IntVal{IntVal: 42}}, want: int64(42)},
+		{name: "bool", val: &gnmiLib.TypedValue{Value: &gnmiLib.TypedValue_BoolVal{BoolVal: true}}, want: true},
+		{name: "json_ietf", val: &gnmiLib.TypedValue{Value: &gnmiLib.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"a":1}`)}},
+			want: map[string]interface{}{"a": float64(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeGNMIValue(tt.val)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeGNMIValue = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeGNMIValueUnsupported(t *testing.T) {
+	if _, err := decodeGNMIValue(&gnmiLib.TypedValue{}); err == nil {
+		t.Fatal("expected error for an unset TypedValue, got nil")
+	}
+}
+
+// TestHandleGNMINotificationDistinctKeyedInstances exercises a single
+// Notification batching updates for two different keyed list instances
+// (as a wildcarded interfaces/interface[name=*] SAMPLE subscription or an
+// initial sync burst would), and asserts they land as two separate points
+// instead of collapsing into one mistagged, last-write-wins point
+func TestHandleGNMINotificationDistinctKeyedInstances(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	c := &CiscoTelemetryMDT{acc: acc}
+
+	notif := &gnmiLib.Notification{
+		Timestamp: 1,
+		Prefix: &gnmiLib.Path{
+			Elem: []*gnmiLib.PathElem{
+				{Name: "interfaces"},
+				{Name: "interface"},
+			},
+		},
+		Update: []*gnmiLib.Update{
+			{
+				Path: &gnmiLib.Path{
+					Elem: []*gnmiLib.PathElem{
+						{Name: "state", Key: map[string]string{"name": "eth0"}},
+						{Name: "counters"},
+						{Name: "in-octets"},
+					},
+				},
+				Val: &gnmiLib.TypedValue{Value: &gnmiLib.TypedValue_IntVal{IntVal: 100}},
+			},
+			{
+				Path: &gnmiLib.Path{
+					Elem: []*gnmiLib.PathElem{
+						{Name: "state", Key: map[string]string{"name": "eth1"}},
+						{Name: "counters"},
+						{Name: "in-octets"},
+					},
+				},
+				Val: &gnmiLib.TypedValue{Value: &gnmiLib.TypedValue_IntVal{IntVal: 200}},
+			},
+		},
+	}
+
+	c.handleGNMINotification(notif)
+
+	if len(acc.Metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2", len(acc.Metrics))
+	}
+
+	for _, m := range acc.Metrics {
+		name := m.Tags["name"]
+		field, ok := m.Fields["state/counters/in-octets"]
+		if !ok {
+			t.Fatalf("metric for name=%q missing state/counters/in-octets field", name)
+		}
+		switch name {
+		case "eth0":
+			if field != int64(100) {
+				t.Errorf("eth0 field = %v, want 100", field)
+			}
+		case "eth1":
+			if field != int64(200) {
+				t.Errorf("eth1 field = %v, want 200", field)
+			}
+		default:
+			t.Errorf("unexpected tag name %q", name)
+		}
+	}
+}
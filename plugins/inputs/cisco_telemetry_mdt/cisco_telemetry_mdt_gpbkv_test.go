@@ -0,0 +1,178 @@
+package cisco_telemetry_mdt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/inputs/cisco_telemetry_mdt/telemetry"
+)
+
+func stringField(name, value string) *telemetry.TelemetryField {
+	return &telemetry.TelemetryField{
+		Name:        name,
+		ValueByType: &telemetry.TelemetryField_StringValue{StringValue: value},
+	}
+}
+
+func bytesField(name string, value []byte) *telemetry.TelemetryField {
+	return &telemetry.TelemetryField{
+		Name:        name,
+		ValueByType: &telemetry.TelemetryField_BytesValue{BytesValue: value},
+	}
+}
+
+func TestParseGPBKVFieldDefault(t *testing.T) {
+	field := stringField("state", "up")
+
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	var namebuf bytes.Buffer
+	parseGPBKVField(field, &namebuf, tags, fields, nil)
+
+	want := map[string]interface{}{"state": "up"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %#v, want %#v", fields, want)
+	}
+}
+
+func TestParseGPBKVFieldNilFieldsIsTag(t *testing.T) {
+	field := stringField("name", "eth0")
+
+	tags := make(map[string]string)
+	var namebuf bytes.Buffer
+	// A nil fields map means the field is under "keys" and is always a tag
+	parseGPBKVField(field, &namebuf, tags, nil, nil)
+
+	if tags["name"] != "eth0" {
+		t.Errorf("tags[name] = %q, want eth0", tags["name"])
+	}
+}
+
+func TestParseGPBKVFieldTagFieldsPromotion(t *testing.T) {
+	field := stringField("name", "eth0")
+	sub := &resolvedSubscription{tagFields: map[string]bool{"name": true}}
+
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	var namebuf bytes.Buffer
+	parseGPBKVField(field, &namebuf, tags, fields, sub)
+
+	if tags["name"] != "eth0" {
+		t.Errorf("tags[name] = %q, want eth0", tags["name"])
+	}
+	if _, ok := fields["name"]; ok {
+		t.Errorf("field promoted to a tag should not also appear in fields")
+	}
+}
+
+func TestParseGPBKVFieldStringsMAC(t *testing.T) {
+	field := bytesField("mac-address", []byte{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01})
+	sub := &resolvedSubscription{fieldStrings: map[string]bool{"mac-address": true}}
+
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	var namebuf bytes.Buffer
+	parseGPBKVField(field, &namebuf, tags, fields, sub)
+
+	want := "de:ad:be:ef:00:01"
+	if fields["mac-address"] != want {
+		t.Errorf("fields[mac-address] = %v, want %v", fields["mac-address"], want)
+	}
+}
+
+func TestParseGPBKVFieldExclude(t *testing.T) {
+	field := stringField("debug-counter", "123")
+	f, err := filter.Compile([]string{"debug-counter"})
+	if err != nil {
+		t.Fatalf("filter.Compile: %v", err)
+	}
+	sub := &resolvedSubscription{fieldExclude: f}
+
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	var namebuf bytes.Buffer
+	parseGPBKVField(field, &namebuf, tags, fields, sub)
+
+	if _, ok := fields["debug-counter"]; ok {
+		t.Errorf("excluded field should not appear in fields")
+	}
+}
+
+func TestParseGPBKVFieldRecursesSubfields(t *testing.T) {
+	field := &telemetry.TelemetryField{
+		Name: "interface",
+		Fields: []*telemetry.TelemetryField{
+			stringField("name", "eth0"),
+			stringField("state", "up"),
+		},
+	}
+
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	var namebuf bytes.Buffer
+	parseGPBKVField(field, &namebuf, tags, fields, nil)
+
+	want := map[string]interface{}{
+		"interface/name":  "eth0",
+		"interface/state": "up",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %#v, want %#v", fields, want)
+	}
+}
+
+func TestLoadSubscriptions(t *testing.T) {
+	c := &CiscoTelemetryMDT{
+		Subscriptions: []CiscoMDTSubscription{
+			{
+				EncodingPath: "interfaces/interface",
+				Name:         "interface",
+				TagFields:    []string{"name"},
+				FieldExclude: []string{"debug-*"},
+				FieldStrings: []string{"mac-address"},
+			},
+		},
+	}
+
+	if err := c.loadSubscriptions(); err != nil {
+		t.Fatalf("loadSubscriptions: %v", err)
+	}
+
+	sub, ok := c.subscriptions["interfaces/interface"]
+	if !ok {
+		t.Fatalf("no resolved subscription for encoding path")
+	}
+	if sub.name != "interface" {
+		t.Errorf("name = %q, want interface", sub.name)
+	}
+	if !sub.tagFields["name"] {
+		t.Errorf("tagFields[name] should be true")
+	}
+	if !sub.fieldStrings["mac-address"] {
+		t.Errorf("fieldStrings[mac-address] should be true")
+	}
+	if sub.fieldExclude == nil || !sub.fieldExclude.Match("debug-counter") {
+		t.Errorf("fieldExclude should match debug-counter")
+	}
+}
+
+func TestLoadSubscriptionsEmpty(t *testing.T) {
+	c := &CiscoTelemetryMDT{}
+	if err := c.loadSubscriptions(); err != nil {
+		t.Fatalf("loadSubscriptions: %v", err)
+	}
+	if c.subscriptions != nil {
+		t.Errorf("subscriptions = %#v, want nil when none configured", c.subscriptions)
+	}
+}
+
+func TestFormatFieldString(t *testing.T) {
+	if got := formatFieldString([]byte{0x01, 0xab}); got != "01:ab" {
+		t.Errorf("formatFieldString(bytes) = %q, want %q", got, "01:ab")
+	}
+	if got := formatFieldString("already-a-string"); got != "already-a-string" {
+		t.Errorf("formatFieldString(string) = %q, want %q", got, "already-a-string")
+	}
+}
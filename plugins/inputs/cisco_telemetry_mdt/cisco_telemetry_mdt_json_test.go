@@ -0,0 +1,174 @@
+package cisco_telemetry_mdt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestFlattenJSONFieldScalar(t *testing.T) {
+	fields := make(map[string]interface{})
+	var namebuf bytes.Buffer
+
+	flattenJSONField("state", "up", &namebuf, nil, fields, nil)
+
+	want := map[string]interface{}{"state": "up"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %#v, want %#v", fields, want)
+	}
+	if namebuf.Len() != 0 {
+		t.Errorf("namebuf not truncated back to empty, got %q", namebuf.String())
+	}
+}
+
+func TestFlattenJSONFieldNestedMap(t *testing.T) {
+	fields := make(map[string]interface{})
+	var namebuf bytes.Buffer
+
+	value := map[string]interface{}{
+		"counters": map[string]interface{}{
+			"in-octets":  float64(100),
+			"out-octets": float64(200),
+		},
+	}
+	flattenJSONField("state", value, &namebuf, nil, fields, nil)
+
+	want := map[string]interface{}{
+		"state/counters/in-octets":  float64(100),
+		"state/counters/out-octets": float64(200),
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %#v, want %#v", fields, want)
+	}
+}
+
+func TestFlattenJSONFieldList(t *testing.T) {
+	fields := make(map[string]interface{})
+	var namebuf bytes.Buffer
+
+	value := []interface{}{"a", "b"}
+	flattenJSONField("members", value, &namebuf, nil, fields, nil)
+
+	want := map[string]interface{}{
+		"members/0": "a",
+		"members/1": "b",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %#v, want %#v", fields, want)
+	}
+}
+
+func TestFlattenJSONFieldAsTag(t *testing.T) {
+	tags := make(map[string]string)
+	var namebuf bytes.Buffer
+
+	flattenJSONField("name", "eth0", &namebuf, tags, nil, nil)
+
+	want := map[string]string{"name": "eth0"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %#v, want %#v", tags, want)
+	}
+}
+
+func TestFlattenJSONFieldTagFieldsPromotion(t *testing.T) {
+	fields := make(map[string]interface{})
+	tags := make(map[string]string)
+	var namebuf bytes.Buffer
+	sub := &resolvedSubscription{tagFields: map[string]bool{"name": true}}
+
+	flattenJSONField("name", "eth0", &namebuf, tags, fields, sub)
+
+	if tags["name"] != "eth0" {
+		t.Errorf("tags[name] = %q, want eth0", tags["name"])
+	}
+	if _, ok := fields["name"]; ok {
+		t.Errorf("field promoted to a tag should not also appear in fields")
+	}
+}
+
+func TestFlattenJSONFieldExclude(t *testing.T) {
+	fields := make(map[string]interface{})
+	tags := make(map[string]string)
+	var namebuf bytes.Buffer
+
+	f, err := filter.Compile([]string{"debug-*"})
+	if err != nil {
+		t.Fatalf("filter.Compile: %v", err)
+	}
+	sub := &resolvedSubscription{fieldExclude: f}
+
+	flattenJSONField("debug-counter", "123", &namebuf, tags, fields, sub)
+
+	if _, ok := fields["debug-counter"]; ok {
+		t.Errorf("excluded field should not appear in fields")
+	}
+}
+
+func TestFlattenJSONFieldStringsMAC(t *testing.T) {
+	fields := make(map[string]interface{})
+	tags := make(map[string]string)
+	var namebuf bytes.Buffer
+	sub := &resolvedSubscription{fieldStrings: map[string]bool{"mac-address": true}}
+
+	flattenJSONField("mac-address", []byte{0xde, 0xad, 0xbe, 0xef}, &namebuf, tags, fields, sub)
+
+	want := "de:ad:be:ef"
+	if fields["mac-address"] != want {
+		t.Errorf("fields[mac-address] = %v, want %v", fields["mac-address"], want)
+	}
+}
+
+// TestHandleJSONTelemetrySubscriptionConfig asserts a [[subscription]] block
+// configured for a JSON-encoded encoding_path is actually applied: renaming
+// the measurement and promoting a content leaf to a tag, the same as it
+// would for a GPBKV- or compact-GPB-encoded message on that path
+func TestHandleJSONTelemetrySubscriptionConfig(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	c := &CiscoTelemetryMDT{
+		acc: acc,
+		subscriptions: map[string]*resolvedSubscription{
+			"interfaces/interface": {
+				name:      "interface",
+				tagFields: map[string]bool{"name": true},
+			},
+		},
+	}
+
+	payload := []byte(`{
+		"node_id_str": "router1",
+		"subscription_id_str": "sub1",
+		"encoding_path": "interfaces/interface",
+		"msg_timestamp": 1000,
+		"data_json": [
+			{
+				"content": {
+					"name": "eth0",
+					"state": "up"
+				}
+			}
+		]
+	}`)
+
+	c.handleJSONTelemetry(payload, nil)
+
+	if len(acc.Metrics) != 1 {
+		t.Fatalf("got %d metrics, want 1", len(acc.Metrics))
+	}
+
+	m := acc.Metrics[0]
+	if m.Measurement != "interface" {
+		t.Errorf("measurement = %q, want %q (from sub.name)", m.Measurement, "interface")
+	}
+	if m.Tags["name"] != "eth0" {
+		t.Errorf("tags[name] = %q, want eth0", m.Tags["name"])
+	}
+	if _, ok := m.Fields["name"]; ok {
+		t.Errorf("field promoted to a tag should not also appear in fields")
+	}
+	if m.Fields["state"] != "up" {
+		t.Errorf("fields[state] = %v, want up", m.Fields["state"])
+	}
+}